@@ -0,0 +1,317 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/livepeer/go-livepeer/clog"
+)
+
+// ParseAIWorkerConcurrency parses an --aiWorkerConcurrency flag value: a comma-separated list of
+// cap=modelID:N entries (e.g. "0=stable-diffusion-xl:2,5=whisper-large-v3:4"), each setting the
+// worker slot count for one (capability, modelID) pair. Entries are optional; an empty spec
+// returns an empty map, leaving every key to fall back to AIJobPool's defaultConcurrency.
+func ParseAIWorkerConcurrency(spec string) (map[AIJobKey]int, error) {
+	concurrency := make(map[AIJobKey]int)
+	if strings.TrimSpace(spec) == "" {
+		return concurrency, nil
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		capModel, nStr, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid --aiWorkerConcurrency entry %q, expected cap=modelID:N", entry)
+		}
+		capStr, modelID, ok := strings.Cut(capModel, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --aiWorkerConcurrency entry %q, expected cap=modelID:N", entry)
+		}
+
+		capInt, err := strconv.Atoi(capStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid capability %q in --aiWorkerConcurrency entry %q: %w", capStr, entry, err)
+		}
+		n, err := strconv.Atoi(nStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid concurrency %q in --aiWorkerConcurrency entry %q: %w", nStr, entry, err)
+		}
+
+		concurrency[AIJobKey{Capability: Capability(capInt), ModelID: modelID}] = n
+	}
+
+	return concurrency, nil
+}
+
+// AIJobKey identifies an independently throttled queue of AI inference jobs.
+type AIJobKey struct {
+	Capability Capability
+	ModelID    string
+}
+
+func (k AIJobKey) String() string {
+	return fmt.Sprintf("%v:%v", k.Capability, k.ModelID)
+}
+
+// ErrAIJobQueueFull is returned by AIJobPool.Submit when the queue for a (capability, modelID)
+// is already at its configured depth. RetryAfter is a hint for how long the caller should wait
+// before retrying, which the HTTP layer surfaces as a 503 + Retry-After response.
+type ErrAIJobQueueFull struct {
+	Key        AIJobKey
+	RetryAfter time.Duration
+}
+
+func (e ErrAIJobQueueFull) Error() string {
+	return fmt.Sprintf("AI job queue full for %v, retry after %v", e.Key, e.RetryAfter)
+}
+
+// AIJobStatus is a point-in-time snapshot of a single (capability, modelID) queue, returned by
+// AIJobPool.Status for the /ai/status debug endpoint.
+type AIJobStatus struct {
+	Capability  Capability `json:"capability"`
+	ModelID     string     `json:"model_id"`
+	Queued      int        `json:"queued"`
+	InFlight    int        `json:"in_flight"`
+	Concurrency int        `json:"concurrency"`
+	Warm        bool       `json:"warm"`
+}
+
+// aiJobFunc is the unit of work an AIJobPool runs: an inference call against the in-process
+// worker or a dispatch to a remote one, returning its result along with any error.
+type aiJobFunc func(context.Context) (interface{}, error)
+
+// aiModelQueue is the bounded FIFO + worker slots backing a single (capability, modelID).
+type aiModelQueue struct {
+	key      AIJobKey
+	sem      chan struct{}
+	queued   int32
+	inFlight int32
+	lastUsed int64 // unix nano, atomic
+
+	warmMu  sync.Mutex
+	warm    bool
+	warmErr error
+	warming chan struct{}
+}
+
+func (q *aiModelQueue) touch() {
+	atomic.StoreInt64(&q.lastUsed, time.Now().UnixNano())
+}
+
+func (q *aiModelQueue) idle() bool {
+	return atomic.LoadInt32(&q.queued) == 0 && atomic.LoadInt32(&q.inFlight) == 0
+}
+
+// AIJobPool runs AI inference jobs (in-process or dispatched to a remote worker), applying a configurable
+// per-(capability, modelID) concurrency limit, bounded queueing with backpressure, and
+// warm/cold model tracking so a request for a cold model waits for Warm() to finish rather than
+// failing outright.
+type AIJobPool struct {
+	mu     sync.Mutex
+	queues map[AIJobKey]*aiModelQueue
+
+	concurrency        map[AIJobKey]int
+	defaultConcurrency int
+	maxQueueDepth      int
+	maxTrackedModels   int
+}
+
+// defaultMaxTrackedAIModels bounds how many distinct (capability, modelID) queues an AIJobPool
+// will track when NewAIJobPool isn't given an explicit limit. modelID comes straight from the
+// request body, so without a bound a caller sending many distinct bogus modelID values could
+// grow the pool's queue map without limit for as long as the orchestrator runs.
+const defaultMaxTrackedAIModels = 256
+
+// NewAIJobPool creates an AIJobPool. concurrency configures the per-(capability, modelID) worker
+// count, falling back to defaultConcurrency for keys that aren't present. maxQueueDepth bounds
+// how many jobs may be queued on top of those already running before Submit returns
+// ErrAIJobQueueFull. maxTrackedModels bounds how many distinct (capability, modelID) queues are
+// tracked at once, evicting the least-recently-used idle one to make room for a new one; <= 0
+// uses defaultMaxTrackedAIModels.
+func NewAIJobPool(concurrency map[AIJobKey]int, defaultConcurrency, maxQueueDepth, maxTrackedModels int) *AIJobPool {
+	limit := maxTrackedModels
+	if limit <= 0 {
+		limit = defaultMaxTrackedAIModels
+	}
+
+	return &AIJobPool{
+		queues:             make(map[AIJobKey]*aiModelQueue),
+		concurrency:        concurrency,
+		defaultConcurrency: defaultConcurrency,
+		maxQueueDepth:      maxQueueDepth,
+		maxTrackedModels:   limit,
+	}
+}
+
+func (p *AIJobPool) queueFor(key AIJobKey) *aiModelQueue {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if q, ok := p.queues[key]; ok {
+		q.touch()
+		return q
+	}
+
+	if len(p.queues) >= p.maxTrackedModels {
+		p.evictOldestIdleLocked()
+	}
+
+	n := p.concurrency[key]
+	if n <= 0 {
+		n = p.defaultConcurrency
+	}
+	if n <= 0 {
+		n = 1
+	}
+
+	q := &aiModelQueue{key: key, sem: make(chan struct{}, n)}
+	q.touch()
+	p.queues[key] = q
+
+	return q
+}
+
+// evictOldestIdleLocked drops the least-recently-used queue that has no queued or in-flight
+// jobs, to make room under maxTrackedModels. p.mu must be held. If every tracked queue is
+// currently busy, it leaves the pool to grow past maxTrackedModels rather than drop active work.
+func (p *AIJobPool) evictOldestIdleLocked() {
+	var oldestKey AIJobKey
+	var oldest *aiModelQueue
+
+	for k, q := range p.queues {
+		if !q.idle() {
+			continue
+		}
+		if oldest == nil || atomic.LoadInt64(&q.lastUsed) < atomic.LoadInt64(&oldest.lastUsed) {
+			oldestKey, oldest = k, q
+		}
+	}
+
+	if oldest != nil {
+		delete(p.queues, oldestKey)
+	}
+}
+
+// EnsureWarm blocks until the model for key is warm. If it is already warm, it returns
+// immediately. Otherwise it runs warmFn to load it, with at most one warmFn in flight per key at
+// a time (singleflight) - concurrent callers for the same cold key all wait on that single call
+// rather than each triggering their own, and all observe its result. Once warmFn succeeds, later
+// calls return immediately without running it again, until the key's queue is evicted as idle
+// (see evictOldestIdleLocked), which discards its warm state. After a failed warmFn, the next
+// call runs it again.
+func (p *AIJobPool) EnsureWarm(ctx context.Context, key AIJobKey, warmFn func(context.Context) error) error {
+	q := p.queueFor(key)
+
+	q.warmMu.Lock()
+	if q.warm {
+		q.warmMu.Unlock()
+		return nil
+	}
+	if ch := q.warming; ch != nil {
+		q.warmMu.Unlock()
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		q.warmMu.Lock()
+		warm, err := q.warm, q.warmErr
+		q.warmMu.Unlock()
+		if warm {
+			return nil
+		}
+		return err
+	}
+
+	ch := make(chan struct{})
+	q.warming = ch
+	q.warmMu.Unlock()
+
+	err := warmFn(ctx)
+
+	q.warmMu.Lock()
+	q.warm = err == nil
+	q.warmErr = err
+	q.warming = nil
+	q.warmMu.Unlock()
+	close(ch)
+
+	return err
+}
+
+// Submit enqueues fn against key's queue and blocks until a worker slot is free and fn has run,
+// the request context is canceled, or the queue is already full. On success it returns fn's
+// result along with how long the job waited in queue before running.
+func (p *AIJobPool) Submit(ctx context.Context, key AIJobKey, fn aiJobFunc) (interface{}, time.Duration, error) {
+	q := p.queueFor(key)
+
+	if p.maxQueueDepth > 0 && int(atomic.LoadInt32(&q.queued)) >= p.maxQueueDepth {
+		return nil, 0, ErrAIJobQueueFull{Key: key, RetryAfter: 2 * time.Second}
+	}
+
+	atomic.AddInt32(&q.queued, 1)
+
+	queuedAt := time.Now()
+
+	select {
+	case q.sem <- struct{}{}:
+	case <-ctx.Done():
+		atomic.AddInt32(&q.queued, -1)
+		return nil, time.Since(queuedAt), ctx.Err()
+	}
+	defer func() { <-q.sem }()
+
+	// Count the job as in flight before it stops counting as queued, so the queue never looks idle
+	// (and evictable) while it holds a slot.
+	atomic.AddInt32(&q.inFlight, 1)
+	defer atomic.AddInt32(&q.inFlight, -1)
+	atomic.AddInt32(&q.queued, -1)
+
+	queueWait := time.Since(queuedAt)
+
+	if ctx.Err() != nil {
+		return nil, queueWait, ctx.Err()
+	}
+
+	clog.V(6).Infof(ctx, "Running AI job key=%v queueWait=%v", key, queueWait)
+
+	val, err := fn(ctx)
+
+	return val, queueWait, err
+}
+
+// Status returns a point-in-time snapshot of every (capability, modelID) queue the pool currently
+// tracks, for the /ai/status debug endpoint. Queues evicted as idle are not included.
+func (p *AIJobPool) Status() []AIJobStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := make([]AIJobStatus, 0, len(p.queues))
+	for key, q := range p.queues {
+		q.warmMu.Lock()
+		warm := q.warm
+		q.warmMu.Unlock()
+
+		status = append(status, AIJobStatus{
+			Capability:  key.Capability,
+			ModelID:     key.ModelID,
+			Queued:      int(atomic.LoadInt32(&q.queued)),
+			InFlight:    int(atomic.LoadInt32(&q.inFlight)),
+			Concurrency: cap(q.sem),
+			Warm:        warm,
+		})
+	}
+
+	return status
+}
@@ -0,0 +1,37 @@
+package core
+
+// Capability identifies an AI worker capability that can be requested by a gateway and priced,
+// authorized, and billed independently by an orchestrator.
+type Capability int
+
+const (
+	Capability_Invalid Capability = iota - 1
+	Capability_TextToImage
+	Capability_ImageToImage
+	Capability_ImageToVideo
+	Capability_TextToVideo
+	Capability_Upscale
+	Capability_AudioToText
+	Capability_LLM
+)
+
+func (c Capability) String() string {
+	switch c {
+	case Capability_TextToImage:
+		return "text-to-image"
+	case Capability_ImageToImage:
+		return "image-to-image"
+	case Capability_ImageToVideo:
+		return "image-to-video"
+	case Capability_TextToVideo:
+		return "text-to-video"
+	case Capability_Upscale:
+		return "upscale"
+	case Capability_AudioToText:
+		return "audio-to-text"
+	case Capability_LLM:
+		return "llm"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,311 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRemoteAIWorkerTimeout is returned when no connected remote AI worker can service a job
+// before its per-job timeout elapses. NextJob also returns it once the polling worker has been
+// evicted.
+var ErrRemoteAIWorkerTimeout = errors.New("remote AI worker timeout")
+
+const (
+	remoteAIWorkerHeartbeatInterval = 10 * time.Second
+	remoteAIWorkerHeartbeatTimeout  = 30 * time.Second
+
+	// remoteAIWorkerJobQueueDepth bounds how many jobs can be queued to a single remote worker
+	// awaiting pickup; once it is full, Dispatch waits for room until its timeout elapses.
+	remoteAIWorkerJobQueueDepth = 8
+)
+
+// RemoteAIWorkerJob is a single unit of work handed to a RemoteAIWorker over the
+// register/poll/result HTTP protocol: the already-bound request re-marshaled to bytes, and a
+// channel its result is delivered on once the worker posts it back.
+type RemoteAIWorkerJob struct {
+	ID          string
+	Key         AIJobKey
+	ContentType string
+	Body        []byte
+
+	result chan RemoteAIWorkerResult
+}
+
+// RemoteAIWorkerResult is what a remote worker reports back for a dispatched job: either the
+// response body it produced, or the error it encountered running the job.
+type RemoteAIWorkerResult struct {
+	ContentType string
+	Body        []byte
+	Err         error
+}
+
+func newRemoteAIWorkerJob(key AIJobKey, contentType string, body []byte) *RemoteAIWorkerJob {
+	return &RemoteAIWorkerJob{
+		ID:          string(RandomManifestID()),
+		Key:         key,
+		ContentType: contentType,
+		Body:        body,
+		result:      make(chan RemoteAIWorkerResult, 1),
+	}
+}
+
+// AIWorkerCapability is the (capability, modelID, warm) tuple a remote worker advertises for
+// itself in its register/heartbeat payload: the pair it is able to serve at all, and whether it
+// currently has that model warm.
+type AIWorkerCapability struct {
+	Key  AIJobKey
+	Warm bool
+}
+
+// RemoteAIWorker represents an external GPU process that has registered with this orchestrator
+// over the /ai/remote-worker HTTP endpoints and advertised the (capability, modelID) pairs it
+// can serve, analogous to a RemoteTranscoder in the existing remote transcoding subsystem. Jobs
+// are handed to it through jobs, which its long-polling /ai/remote-worker/jobs request drains.
+type RemoteAIWorker struct {
+	ID string
+
+	mu       sync.Mutex
+	supports map[AIJobKey]bool
+	warm     map[AIJobKey]bool
+	lastSeen time.Time
+
+	jobs chan *RemoteAIWorkerJob
+	done chan struct{}
+}
+
+// NewRemoteAIWorker registers a new remote worker advertising the given capabilities.
+func NewRemoteAIWorker(id string, caps []AIWorkerCapability) *RemoteAIWorker {
+	w := &RemoteAIWorker{
+		ID:       id,
+		supports: make(map[AIJobKey]bool, len(caps)),
+		warm:     make(map[AIJobKey]bool, len(caps)),
+		lastSeen: time.Now(),
+		jobs:     make(chan *RemoteAIWorkerJob, remoteAIWorkerJobQueueDepth),
+		done:     make(chan struct{}),
+	}
+	w.setCapabilities(caps)
+
+	return w
+}
+
+// Supports reports whether the worker advertised it can serve key at all, warm or not.
+func (w *RemoteAIWorker) Supports(key AIJobKey) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.supports[key]
+}
+
+// IsWarm reports whether the worker has already loaded the model for key.
+func (w *RemoteAIWorker) IsWarm(key AIJobKey) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.warm[key]
+}
+
+// Heartbeat refreshes the worker's last-seen time and advertised capabilities. It should be
+// called whenever the worker's heartbeat request delivers an update.
+func (w *RemoteAIWorker) Heartbeat(caps []AIWorkerCapability) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lastSeen = time.Now()
+	w.setCapabilities(caps)
+}
+
+// setCapabilities replaces the worker's supports/warm sets wholesale from caps. Callers must hold
+// w.mu.
+func (w *RemoteAIWorker) setCapabilities(caps []AIWorkerCapability) {
+	w.supports = make(map[AIJobKey]bool, len(caps))
+	w.warm = make(map[AIJobKey]bool, len(caps))
+	for _, c := range caps {
+		w.supports[c.Key] = true
+		if c.Warm {
+			w.warm[c.Key] = true
+		}
+	}
+}
+
+func (w *RemoteAIWorker) expired() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return time.Since(w.lastSeen) > remoteAIWorkerHeartbeatTimeout
+}
+
+// NextJob blocks until a job is handed to the worker, ctx is canceled, the worker is evicted, or
+// timeout elapses with nothing to run - in which case it returns a nil job so the caller's
+// long-poll request can return an empty response and the worker can immediately re-poll.
+func (w *RemoteAIWorker) NextJob(ctx context.Context, timeout time.Duration) (*RemoteAIWorkerJob, error) {
+	select {
+	case job := <-w.jobs:
+		return job, nil
+	case <-w.done:
+		return nil, ErrRemoteAIWorkerTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(timeout):
+		return nil, nil
+	}
+}
+
+// Close evicts the worker. Any job already dispatched to it is left to fail on its own context
+// deadline.
+func (w *RemoteAIWorker) Close() {
+	select {
+	case <-w.done:
+	default:
+		close(w.done)
+	}
+}
+
+// RemoteAIWorkerManager dispatches AI inference jobs to registered RemoteAIWorker processes
+// instead of running them against a local, in-process worker, preferring workers that already
+// have the requested model warm (model affinity), evicting workers whose heartbeat has lapsed,
+// and correlating asynchronously-posted results back to the Dispatch call waiting on them.
+type RemoteAIWorkerManager struct {
+	mu      sync.Mutex
+	workers map[string]*RemoteAIWorker
+	pending map[string]*RemoteAIWorkerJob
+}
+
+// NewRemoteAIWorkerManager creates an empty RemoteAIWorkerManager and starts its eviction loop,
+// which runs until ctx is canceled.
+func NewRemoteAIWorkerManager(ctx context.Context) *RemoteAIWorkerManager {
+	m := &RemoteAIWorkerManager{
+		workers: make(map[string]*RemoteAIWorker),
+		pending: make(map[string]*RemoteAIWorkerJob),
+	}
+	go m.evictLoop(ctx)
+
+	return m
+}
+
+// Register adds a newly connected remote worker to the pool. It is called by the
+// /ai/remote-worker/register HTTP handler once a worker has authenticated via mTLS.
+func (m *RemoteAIWorkerManager) Register(w *RemoteAIWorker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.workers[w.ID] = w
+}
+
+// Unregister removes a worker, e.g. on disconnect or an explicit deregister call, and closes it.
+func (m *RemoteAIWorkerManager) Unregister(id string) {
+	m.mu.Lock()
+	w, ok := m.workers[id]
+	delete(m.workers, id)
+	m.mu.Unlock()
+
+	if ok {
+		w.Close()
+	}
+}
+
+// Worker looks up a registered worker by ID, for the poll/heartbeat/result HTTP handlers acting
+// on behalf of an already-registered worker.
+func (m *RemoteAIWorkerManager) Worker(id string) *RemoteAIWorker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.workers[id]
+}
+
+func (m *RemoteAIWorkerManager) evictLoop(ctx context.Context) {
+	t := time.NewTicker(remoteAIWorkerHeartbeatInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			m.mu.Lock()
+			for id, w := range m.workers {
+				if w.expired() {
+					delete(m.workers, id)
+					w.Close()
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// Pick selects a registered worker that supports key, preferring one that already has the model
+// warm, and returns nil if no connected worker supports key at all.
+func (m *RemoteAIWorkerManager) Pick(key AIJobKey) *RemoteAIWorker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var cold *RemoteAIWorker
+	for _, w := range m.workers {
+		if !w.Supports(key) {
+			continue
+		}
+		if w.IsWarm(key) {
+			return w
+		}
+		if cold == nil {
+			cold = w
+		}
+	}
+
+	return cold
+}
+
+// Dispatch hands body to w over the register/poll/result HTTP protocol and blocks until the
+// worker posts its result, ctx is canceled, or timeout elapses; timeout covers waiting for room
+// in w's job queue and for the result together. It returns ErrRemoteAIWorkerTimeout if w's job
+// queue stays full or w does not poll and respond before timeout elapses.
+func (m *RemoteAIWorkerManager) Dispatch(ctx context.Context, key AIJobKey, timeout time.Duration, w *RemoteAIWorker, contentType string, body []byte) (*RemoteAIWorkerResult, error) {
+	job := newRemoteAIWorkerJob(key, contentType, body)
+
+	m.mu.Lock()
+	m.pending[job.ID] = job
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.pending, job.ID)
+		m.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case w.jobs <- job:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, ErrRemoteAIWorkerTimeout
+	}
+
+	select {
+	case res := <-job.result:
+		return &res, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, ErrRemoteAIWorkerTimeout
+	}
+}
+
+// CompleteJob delivers a worker's posted result for a previously dispatched job to the Dispatch
+// call awaiting it. It is called by the /ai/remote-worker/result HTTP handler and returns false
+// if jobID is not (or is no longer) pending.
+func (m *RemoteAIWorkerManager) CompleteJob(jobID string, res RemoteAIWorkerResult) bool {
+	m.mu.Lock()
+	job, ok := m.pending[jobID]
+	m.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	job.result <- res
+
+	return true
+}
@@ -1,20 +1,33 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/binary"
 	"encoding/json"
+	"fmt"
 	"image"
+	"io"
+	"math"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"os"
 	"strconv"
 	"time"
 
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/livepeer/ai-worker/worker"
 	"github.com/livepeer/go-livepeer/clog"
 	"github.com/livepeer/go-livepeer/common"
 	"github.com/livepeer/go-livepeer/core"
+	"github.com/livepeer/go-livepeer/net"
+	"github.com/livepeer/lpms/ffmpeg"
 	middleware "github.com/oapi-codegen/nethttp-middleware"
 	"github.com/oapi-codegen/runtime"
+	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
 func startAIServer(lp lphttp) error {
@@ -41,10 +54,91 @@ func startAIServer(lp lphttp) error {
 	lp.transRPC.Handle("/image-to-image", oapiReqValidator(lp.ImageToImage()))
 	lp.transRPC.Handle("/image-to-video", oapiReqValidator(lp.ImageToVideo()))
 	lp.transRPC.Handle("/text-to-video", oapiReqValidator(lp.TextToVideo()))
+	lp.transRPC.Handle("/llm", oapiReqValidator(lp.LLM()))
+	lp.transRPC.Handle("/audio-to-text", oapiReqValidator(lp.AudioToText()))
+	lp.transRPC.Handle("/upscale", oapiReqValidator(lp.Upscale()))
+	lp.transRPC.Handle("/ai/status", lp.AIStatus())
+
+	registerAIRemoteWorkerRoutes(lp)
 
 	return nil
 }
 
+// defaultAIWorkerConcurrency is used for any (capability, modelID) not given an explicit slot
+// count via LP_AI_WORKER_CONCURRENCY, high enough that a capability/model isn't serialized down
+// to one job at a time by default.
+const defaultAIWorkerConcurrency = 4
+
+// defaultAIWorkerQueueDepth bounds how many jobs may queue for each (capability, modelID) pair,
+// on top of those already running, before a request gets ErrAIJobQueueFull.
+const defaultAIWorkerQueueDepth = 16
+
+// aiJobPool throttles and queues AI inference jobs across all AI HTTP handlers, independently
+// per (capability, modelID). Per-(capability, modelID) concurrency is read from the
+// LP_AI_WORKER_CONCURRENCY env var (a comma-separated cap=modelID:N list) as a stand-in for an
+// --aiWorkerConcurrency CLI flag, since cmd/livepeer isn't part of this package; anything not
+// listed there falls back to defaultAIWorkerConcurrency.
+var aiJobPool = newAIJobPool()
+
+func newAIJobPool() *core.AIJobPool {
+	concurrency, err := core.ParseAIWorkerConcurrency(os.Getenv("LP_AI_WORKER_CONCURRENCY"))
+	if err != nil {
+		clog.Errorf(context.Background(), "Invalid LP_AI_WORKER_CONCURRENCY, ignoring and using defaults err=%v", err)
+		concurrency = nil
+	}
+
+	return core.NewAIJobPool(concurrency, defaultAIWorkerConcurrency, defaultAIWorkerQueueDepth, 0)
+}
+
+// aiStatusToken gates the /ai/status debug endpoint. It is read from the LP_AI_STATUS_TOKEN env
+// var (a stand-in for an orchestrator config flag); while unset, /ai/status refuses every
+// request, since it's registered on the same public transRPC mux as the paid AI endpoints and
+// would otherwise leak queue depth, in-flight counts, and warm-model info to any caller, unlike
+// every other handler here, which all go through handleAIRequest's sender/payment verification.
+var aiStatusToken = os.Getenv("LP_AI_STATUS_TOKEN")
+
+// AIStatus exposes the queue depth, in-flight job count, and warm state of every AI model the
+// pool currently tracks (see AIJobPool.Status), for operators debugging load/backpressure. It requires a bearer
+// token matching aiStatusToken, since it carries no payment/session verification of its own.
+func (h *lphttp) AIStatus() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if aiStatusToken == "" || r.Header.Get("Authorization") != "Bearer "+aiStatusToken {
+			respondWithError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(aiJobPool.Status())
+	})
+}
+
+// maxAIRequestBodyBytes bounds how much of an AI request body (JSON or multipart) is buffered
+// into memory, both so a misbehaving upload can't exhaust server memory and so the body can be
+// kept around, unmodified, to forward verbatim to a remote AI worker (see
+// dispatchToRemoteAIWorker) instead of re-marshaling it. AudioToText, whose uploads are the one
+// capability expected to be large, is deliberately excluded from this buffering (see
+// probeAudioDurationSecs and the AudioToText handler) rather than having this limit raised to
+// cover it, so a handful of concurrent requests can't hold gigabytes of upload in memory at once.
+const maxAIRequestBodyBytes = 64 * 1024 * 1024 // 64 MiB
+
+// readLimitedBody reads r's body into memory, up to limit bytes, erroring if the body is larger.
+func readLimitedBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	return io.ReadAll(r.Body)
+}
+
+// multipartReader builds a multipart.Reader over an already-buffered body, using the boundary
+// from r's Content-Type header, so the body can be bound into a request struct and separately
+// kept, unmodified, for forwarding to a remote AI worker.
+func multipartReader(r *http.Request, body []byte) (*multipart.Reader, error) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	return multipart.NewReader(bytes.NewReader(body), params["boundary"]), nil
+}
+
 func (h *lphttp) TextToImage() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		orch := h.orchestrator
@@ -52,13 +146,19 @@ func (h *lphttp) TextToImage() http.Handler {
 		remoteAddr := getRemoteAddr(r)
 		ctx := clog.AddVal(r.Context(), clog.ClientIP, remoteAddr)
 
+		body, err := readLimitedBody(w, r, maxAIRequestBodyBytes)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		var req worker.TextToImageJSONRequestBody
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := json.Unmarshal(body, &req); err != nil {
 			respondWithError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		handleAIRequest(ctx, w, r, orch, req)
+		handleAIRequest(ctx, w, r, orch, req, r.Header.Get("Content-Type"), body)
 	})
 }
 
@@ -69,7 +169,12 @@ func (h *lphttp) ImageToImage() http.Handler {
 		remoteAddr := getRemoteAddr(r)
 		ctx := clog.AddVal(r.Context(), clog.ClientIP, remoteAddr)
 
-		multiRdr, err := r.MultipartReader()
+		body, err := readLimitedBody(w, r, maxAIRequestBodyBytes)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		multiRdr, err := multipartReader(r, body)
 		if err != nil {
 			respondWithError(w, err.Error(), http.StatusBadRequest)
 			return
@@ -81,7 +186,7 @@ func (h *lphttp) ImageToImage() http.Handler {
 			return
 		}
 
-		handleAIRequest(ctx, w, r, orch, req)
+		handleAIRequest(ctx, w, r, orch, req, r.Header.Get("Content-Type"), body)
 	})
 }
 
@@ -92,7 +197,12 @@ func (h *lphttp) ImageToVideo() http.Handler {
 		remoteAddr := getRemoteAddr(r)
 		ctx := clog.AddVal(r.Context(), clog.ClientIP, remoteAddr)
 
-		multiRdr, err := r.MultipartReader()
+		body, err := readLimitedBody(w, r, maxAIRequestBodyBytes)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		multiRdr, err := multipartReader(r, body)
 		if err != nil {
 			respondWithError(w, err.Error(), http.StatusBadRequest)
 			return
@@ -104,7 +214,7 @@ func (h *lphttp) ImageToVideo() http.Handler {
 			return
 		}
 
-		handleAIRequest(ctx, w, r, orch, req)
+		handleAIRequest(ctx, w, r, orch, req, r.Header.Get("Content-Type"), body)
 	})
 }
 
@@ -115,17 +225,317 @@ func (h *lphttp) TextToVideo() http.Handler {
 		remoteAddr := getRemoteAddr(r)
 		ctx := clog.AddVal(r.Context(), clog.ClientIP, remoteAddr)
 
+		body, err := readLimitedBody(w, r, maxAIRequestBodyBytes)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		var req worker.TextToVideoJSONRequestBody
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := json.Unmarshal(body, &req); err != nil {
 			respondWithError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		handleAIRequest(ctx, w, r, orch, req)
+		handleAIRequest(ctx, w, r, orch, req, r.Header.Get("Content-Type"), body)
+	})
+}
+
+func (h *lphttp) LLM() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orch := h.orchestrator
+
+		remoteAddr := getRemoteAddr(r)
+		ctx := clog.AddVal(r.Context(), clog.ClientIP, remoteAddr)
+
+		body, err := readLimitedBody(w, r, maxAIRequestBodyBytes)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		multiRdr, err := multipartReader(r, body)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var req worker.LLMFormdataRequestBody
+		if err := runtime.BindMultipart(&req, *multiRdr); err != nil {
+			respondWithError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		handleAIRequest(ctx, w, r, orch, req, r.Header.Get("Content-Type"), body)
+	})
+}
+
+// maxAudioUploadBytes bounds how much of an uploaded audio file is buffered to disk so a
+// misbehaving or oversized upload cannot exhaust server memory/disk. It is independent of, and
+// much larger than, what a single track needs for ffmpeg to locate its duration atom - the whole
+// point is to probe the real file rather than a truncated prefix of it.
+const maxAudioUploadBytes = 2 * 1024 * 1024 * 1024 // 2 GiB
+
+// probeAudioDurationSecs buffers the uploaded audio to a bounded temp file and probes its
+// duration with ffmpeg. A probe failure (unrecognized or corrupt container) fails the request
+// rather than billing a flat fallback duration - silently undercharging here would let a client
+// get near-free transcription of arbitrarily long audio just by uploading something that fails
+// to probe.
+func probeAudioDurationSecs(ctx context.Context, audio openapi_types.File) (int64, error) {
+	rdr, err := audio.Reader()
+	if err != nil {
+		return 0, err
+	}
+
+	tmpFile, err := os.CreateTemp("", "livepeer-audio-to-text-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	written, err := io.CopyN(tmpFile, rdr, maxAudioUploadBytes+1)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	if written > maxAudioUploadBytes {
+		return 0, fmt.Errorf("audio upload exceeds the %d byte limit", maxAudioUploadBytes)
+	}
+
+	status, err := ffmpeg.GetCodecInfo(tmpFile.Name())
+	if err != nil {
+		return 0, fmt.Errorf("could not probe audio duration: %w", err)
+	}
+
+	return int64(math.Ceil(status.DurSecs)), nil
+}
+
+// newSeed generates a random generation seed so that a request can be reproduced later even if
+// the caller did not supply one.
+func newSeed() (uint64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+// RequestMetadata is returned alongside the generated media for every image/video request so
+// that a caller can log or reproduce the request deterministically.
+type RequestMetadata struct {
+	RequestID   string   `json:"request_id"`
+	ModelID     string   `json:"model_id"`
+	Capability  string   `json:"capability"`
+	TookMs      int64    `json:"took_ms"`
+	OutputUnits int64    `json:"output_units"`
+	Seeds       []uint64 `json:"seeds,omitempty"`
+}
+
+// imageResponseEnvelope wraps a worker.ImageResponse's images together with the RequestMetadata
+// for the request that produced them.
+type imageResponseEnvelope struct {
+	Images          []worker.Media  `json:"images"`
+	RequestMetadata RequestMetadata `json:"request_metadata"`
+}
+
+// AudioToText reads its multipart body directly off the connection rather than through
+// readLimitedBody/multipartReader like the other AI handlers, since an audio upload is the one
+// capability expected to be large; probeAudioDurationSecs already bounds it to a temp file rather
+// than memory, and buffering the whole upload here first would defeat that. As a result audio
+// requests are not eligible for remote AI worker dispatch today - see the remote-dispatch guard in
+// handleAIRequest.
+func (h *lphttp) AudioToText() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orch := h.orchestrator
+
+		remoteAddr := getRemoteAddr(r)
+		ctx := clog.AddVal(r.Context(), clog.ClientIP, remoteAddr)
+
+		multiRdr, err := r.MultipartReader()
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var req worker.AudioToTextMultipartRequestBody
+		if err := runtime.BindMultipart(&req, *multiRdr); err != nil {
+			respondWithError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		handleAIRequest(ctx, w, r, orch, req, "", nil)
+	})
+}
+
+func (h *lphttp) Upscale() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orch := h.orchestrator
+
+		remoteAddr := getRemoteAddr(r)
+		ctx := clog.AddVal(r.Context(), clog.ClientIP, remoteAddr)
+
+		body, err := readLimitedBody(w, r, maxAIRequestBodyBytes)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		multiRdr, err := multipartReader(r, body)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var req worker.UpscaleMultipartRequestBody
+		if err := runtime.BindMultipart(&req, *multiRdr); err != nil {
+			respondWithError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		handleAIRequest(ctx, w, r, orch, req, r.Header.Get("Content-Type"), body)
+	})
+}
+
+// costEstimator computes the billing units and the name of the billing unit for a request along
+// whichever dimension is relevant to its capability (pixels, seconds of audio, tokens, ...).
+type costEstimator interface {
+	EstimateUnits(ctx context.Context) (units int64, unit string, err error)
+}
+
+// costEstimatorFunc adapts a plain function to a costEstimator, mirroring http.HandlerFunc.
+type costEstimatorFunc func(ctx context.Context) (int64, string, error)
+
+func (f costEstimatorFunc) EstimateUnits(ctx context.Context) (int64, string, error) {
+	return f(ctx)
+}
+
+// pixelsEstimator bills a fixed pixel count computed up front from the request parameters.
+func pixelsEstimator(pixels int64) costEstimator {
+	return costEstimatorFunc(func(ctx context.Context) (int64, string, error) {
+		return pixels, "pixels", nil
+	})
+}
+
+// secondsEstimator bills a fixed duration, in seconds, computed up front from the request.
+func secondsEstimator(seconds int64) costEstimator {
+	return costEstimatorFunc(func(ctx context.Context) (int64, string, error) {
+		return seconds, "seconds", nil
 	})
 }
 
-func handleAIRequest(ctx context.Context, w http.ResponseWriter, r *http.Request, orch Orchestrator, req interface{}) {
+// tokensEstimator bills zero tokens up front; the LLM capability only knows its token count once
+// the worker's response has been received, at which point handleAIRequest overrides this value.
+func tokensEstimator() costEstimator {
+	return costEstimatorFunc(func(ctx context.Context) (int64, string, error) {
+		return 0, "tokens", nil
+	})
+}
+
+// priceTooLowError is the structured 402 body returned when a gateway's payment does not meet
+// the orchestrator's configured price for the requested capability + modelID, so the gateway
+// can retry the job with a fresh OrchestratorInfo for the updated price.
+type priceTooLowError struct {
+	Price *net.PriceInfo `json:"price"`
+}
+
+func respondWithPriceError(w http.ResponseWriter, price *net.PriceInfo) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPaymentRequired)
+	_ = json.NewEncoder(w).Encode(priceTooLowError{Price: price})
+}
+
+// sufficientPrice reports whether the expected price offered by the gateway meets or exceeds the
+// orchestrator's configured price. The per-unit prices are fractions (price/pixels), so they are
+// compared by cross-multiplication to avoid division.
+func sufficientPrice(expected, configured *net.PriceInfo) bool {
+	if configured.GetPricePerUnit() == 0 {
+		return true
+	}
+	if expected == nil {
+		return false
+	}
+
+	return expected.GetPricePerUnit()*configured.GetPixelsPerUnit() >= configured.GetPricePerUnit()*expected.GetPixelsPerUnit()
+}
+
+// checkAndProcessPayment looks up the orchestrator's price for cap+modelID, rejects payment that
+// doesn't meet it, processes the payment, and checks the resulting balance is sufficient. It
+// writes the appropriate error response and returns false on any failure, in which case the
+// caller must stop handling the request.
+func checkAndProcessPayment(ctx context.Context, w http.ResponseWriter, orch Orchestrator, sender ethcommon.Address, payment *net.Payment, manifestID core.ManifestID, cap core.Capability, modelID string) bool {
+	price, err := orch.PriceInfo(sender, manifestID, cap, modelID)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	if !sufficientPrice(payment.GetExpectedPrice(), price) {
+		respondWithPriceError(w, price)
+		return false
+	}
+
+	// Known limitation:
+	// This call will set a fixed price for all requests in a session identified by a manifestID.
+	// Since all requests for a capability + modelID are treated as "session" with a single manifestID, all
+	// requests for a capability + modelID will get the same fixed price for as long as the orch is running
+	if err := orch.ProcessPayment(ctx, payment, manifestID, cap, modelID); err != nil {
+		respondWithError(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	if payment.GetExpectedPrice().GetPricePerUnit() > 0 && !orch.SufficientBalance(sender, manifestID) {
+		respondWithError(w, "Insufficient balance", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// llmStreamResult is returned by the LLM case's submitFn in place of the raw chunk channel, once
+// it has already streamed the response out as SSE (see writeLLMStream) from inside
+// aiJobPool.Submit, so handleAIRequest knows to just debit tokensUsed rather than write a body.
+type llmStreamResult struct {
+	tokensUsed int64
+}
+
+// writeLLMStream forwards LLM token chunks to the client as Server-Sent Events, terminating with
+// a "data: [DONE]" event once the chunk channel closes. It returns the number of output tokens
+// reported on the final chunk so the caller can debit fees accordingly.
+func writeLLMStream(ctx context.Context, w http.ResponseWriter, requestID string, chunks chan worker.LLMResponse) int64 {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		clog.Errorf(ctx, "Could not flush streaming response id=%v", requestID)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	var tokensUsed int64
+	for chunk := range chunks {
+		tokensUsed = int64(chunk.TokensUsed)
+
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			clog.Errorf(ctx, "Could not marshal LLM stream chunk id=%v err=%v", requestID, err)
+			continue
+		}
+
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if ok {
+			flusher.Flush()
+		}
+	}
+
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if ok {
+		flusher.Flush()
+	}
+
+	return tokensUsed
+}
+
+func handleAIRequest(ctx context.Context, w http.ResponseWriter, r *http.Request, orch Orchestrator, req interface{}, contentType string, rawBody []byte) {
 	payment, err := getPayment(r.Header.Get(paymentHeader))
 	if err != nil {
 		respondWithError(w, err.Error(), http.StatusPaymentRequired)
@@ -139,16 +549,28 @@ func handleAIRequest(ctx context.Context, w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	requestID := string(core.RandomManifestID())
+
 	var cap core.Capability
 	var modelID string
-	var submitFn func(context.Context) (*worker.ImageResponse, error)
-	var outPixels int64
+	var submitFn func(context.Context) (interface{}, error)
+	var estimator costEstimator
 
 	switch v := req.(type) {
 	case worker.TextToImageJSONRequestBody:
 		cap = core.Capability_TextToImage
 		modelID = *v.ModelId
-		submitFn = func(ctx context.Context) (*worker.ImageResponse, error) {
+
+		if v.Seed == nil {
+			seed, err := newSeed()
+			if err != nil {
+				respondWithError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			v.Seed = &seed
+		}
+
+		submitFn = func(ctx context.Context) (interface{}, error) {
 			return orch.TextToImage(ctx, v)
 		}
 
@@ -162,11 +584,21 @@ func handleAIRequest(ctx context.Context, w http.ResponseWriter, r *http.Request
 			width = int64(*v.Width)
 		}
 
-		outPixels = height * width
+		estimator = pixelsEstimator(height * width)
 	case worker.ImageToImageMultipartRequestBody:
 		cap = core.Capability_ImageToImage
 		modelID = *v.ModelId
-		submitFn = func(ctx context.Context) (*worker.ImageResponse, error) {
+
+		if v.Seed == nil {
+			seed, err := newSeed()
+			if err != nil {
+				respondWithError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			v.Seed = &seed
+		}
+
+		submitFn = func(ctx context.Context) (interface{}, error) {
 			return orch.ImageToImage(ctx, v)
 		}
 
@@ -180,11 +612,21 @@ func handleAIRequest(ctx context.Context, w http.ResponseWriter, r *http.Request
 			respondWithError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		outPixels = int64(config.Height) * int64(config.Width)
+		estimator = pixelsEstimator(int64(config.Height) * int64(config.Width))
 	case worker.ImageToVideoMultipartRequestBody:
 		cap = core.Capability_ImageToVideo
 		modelID = *v.ModelId
-		submitFn = func(ctx context.Context) (*worker.ImageResponse, error) {
+
+		if v.Seed == nil {
+			seed, err := newSeed()
+			if err != nil {
+				respondWithError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			v.Seed = &seed
+		}
+
+		submitFn = func(ctx context.Context) (interface{}, error) {
 			return orch.ImageToVideo(ctx, v)
 		}
 
@@ -200,11 +642,21 @@ func handleAIRequest(ctx context.Context, w http.ResponseWriter, r *http.Request
 		// The # of frames outputted by stable-video-diffusion-img2vid-xt models
 		frames := int64(25)
 
-		outPixels = height * width * int64(frames)
+		estimator = pixelsEstimator(height * width * frames)
 	case worker.TextToVideoJSONRequestBody:
 		cap = core.Capability_TextToVideo
 		modelID = *v.ModelId
-		submitFn = func(ctx context.Context) (*worker.ImageResponse, error) {
+
+		if v.Seed == nil {
+			seed, err := newSeed()
+			if err != nil {
+				respondWithError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			v.Seed = &seed
+		}
+
+		submitFn = func(ctx context.Context) (interface{}, error) {
 			return orch.TextToVideo(ctx, v)
 		}
 
@@ -220,29 +672,76 @@ func handleAIRequest(ctx context.Context, w http.ResponseWriter, r *http.Request
 		// The # of frames outputted by stable-video-diffusion-img2vid-xt models
 		frames := int64(25)
 
-		outPixels = height * width * int64(frames)
+		estimator = pixelsEstimator(height * width * frames)
+	case worker.LLMFormdataRequestBody:
+		cap = core.Capability_LLM
+		modelID = *v.ModelId
+		submitFn = func(ctx context.Context) (interface{}, error) {
+			resp, err := orch.LLM(ctx, v)
+			if err != nil {
+				return nil, err
+			}
+
+			// A stream=true request returns its tokens as SSE frames as they are generated. The
+			// stream is drained here, inside submitFn, rather than after aiJobPool.Submit returns,
+			// so the job pool's concurrency slot is held and the timing logged below covers the
+			// full generation, not just however long it took the first chunk to arrive.
+			if chunks, ok := resp.(chan worker.LLMResponse); ok {
+				tokensUsed := writeLLMStream(ctx, w, requestID, chunks)
+				return &llmStreamResult{tokensUsed: tokensUsed}, nil
+			}
+
+			return resp, nil
+		}
+		estimator = tokensEstimator()
+	case worker.AudioToTextMultipartRequestBody:
+		cap = core.Capability_AudioToText
+		modelID = *v.ModelId
+		submitFn = func(ctx context.Context) (interface{}, error) {
+			return orch.AudioToText(ctx, v)
+		}
+
+		durSecs, err := probeAudioDurationSecs(ctx, v.Audio)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		estimator = secondsEstimator(durSecs)
+	case worker.UpscaleMultipartRequestBody:
+		cap = core.Capability_Upscale
+		modelID = *v.ModelId
+		submitFn = func(ctx context.Context) (interface{}, error) {
+			return orch.Upscale(ctx, v)
+		}
+
+		imageRdr, err := v.Image.Reader()
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		config, _, err := image.DecodeConfig(imageRdr)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		scale := float64(4)
+		if v.Scale != nil {
+			scale = float64(*v.Scale)
+		}
+		inPixels := int64(config.Height) * int64(config.Width)
+
+		estimator = pixelsEstimator(int64(float64(inPixels) * scale * scale))
 	default:
 		respondWithError(w, "Unknown request type", http.StatusBadRequest)
 		return
 	}
 
-	requestID := string(core.RandomManifestID())
-
 	clog.V(common.VERBOSE).Infof(ctx, "Received request id=%v cap=%v modelID=%v", requestID, cap, modelID)
 
 	manifestID := core.ManifestID(strconv.Itoa(int(cap)) + "_" + modelID)
 
-	// Known limitation:
-	// This call will set a fixed price for all requests in a session identified by a manifestID.
-	// Since all requests for a capability + modelID are treated as "session" with a single manifestID, all
-	// requests for a capability + modelID will get the same fixed price for as long as the orch is running
-	if err := orch.ProcessPayment(ctx, payment, manifestID); err != nil {
-		respondWithError(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	if payment.GetExpectedPrice().GetPricePerUnit() > 0 && !orch.SufficientBalance(sender, manifestID) {
-		respondWithError(w, "Insufficient balance", http.StatusBadRequest)
+	if !checkAndProcessPayment(ctx, w, orch, sender, payment, manifestID, cap, modelID) {
 		return
 	}
 
@@ -252,22 +751,111 @@ func handleAIRequest(ctx context.Context, w http.ResponseWriter, r *http.Request
 	// by the orch during discovery. In that scenario, the broadcaster can use a GetOrchestrator() RPC call to get a
 	// a new OrchestratorInfo before submitting a request.
 
+	jobKey := core.AIJobKey{Capability: cap, ModelID: modelID}
+
+	// Prefer a connected remote AI worker over the in-process worker when one is registered and
+	// capable of serving this (capability, modelID). The original request body is forwarded
+	// unchanged (not re-marshaled) so multipart fields such as an uploaded source image round-trip
+	// to the worker intact; submitFn otherwise keeps running jobs in-process as before.
+	//
+	// This is skipped for LLM, since the register/poll/result protocol has no provision for
+	// streaming the SSE chunks a stream=true request expects back, and for any request whose
+	// handler didn't capture rawBody (currently just AudioToText, see its handler), since there is
+	// nothing to forward.
+	//
+	// pickRemoteAIWorker only decides whether a remote worker exists for jobKey, so EnsureWarm can
+	// be skipped below; the dispatch round trip itself happens inside runFn, below
+	// aiJobPool.Submit, so it counts against that (capability, modelID)'s concurrency and its
+	// duration is reflected in queueWait/took like an in-process job's would be. runFn re-picks a
+	// worker right before dispatching rather than reusing the one found here, since this request
+	// may have waited on aiJobPool's semaphore in between, and the one found here could have gone
+	// stale by the time it actually runs.
+	runFn := submitFn
+	remoteDispatch := false
+	if cap != core.Capability_LLM && rawBody != nil && pickRemoteAIWorker(jobKey) != nil {
+		remoteDispatch = true
+		runFn = func(ctx context.Context) (interface{}, error) {
+			remoteWorker := pickRemoteAIWorker(jobKey)
+			if remoteWorker == nil {
+				return nil, core.ErrRemoteAIWorkerTimeout
+			}
+
+			remoteRes, err := dispatchToRemoteAIWorker(ctx, remoteWorker, jobKey, contentType, rawBody)
+			if err != nil {
+				return nil, err
+			}
+			if remoteRes.Err != nil {
+				return nil, remoteRes.Err
+			}
+
+			return parseRemoteAIWorkerResponse(cap, remoteRes.Body)
+		}
+	}
+	if !remoteDispatch {
+		if err := aiJobPool.EnsureWarm(ctx, jobKey, func(ctx context.Context) error {
+			return orch.Warm(ctx, cap, modelID)
+		}); err != nil {
+			respondWithError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	start := time.Now()
-	resp, err := submitFn(ctx)
+	resp, queueWait, err := aiJobPool.Submit(ctx, jobKey, runFn)
 	if err != nil {
+		if full, ok := err.(core.ErrAIJobQueueFull); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(full.RetryAfter.Seconds())))
+			respondWithError(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		respondWithError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	took := time.Since(start)
-	clog.Infof(ctx, "Processed request id=%v cap=%v modelID=%v took=%v", requestID, cap, modelID, took)
+	clog.Infof(ctx, "Processed request id=%v cap=%v modelID=%v queueWait=%v inferenceTook=%v", requestID, cap, modelID, queueWait, took-queueWait)
+
+	// LLM requests made with stream=true have already been streamed out as SSE frames by submitFn
+	// (see the LLM case above) and are billed on the token count it reported.
+	if streamed, ok := resp.(*llmStreamResult); ok {
+		orch.DebitFees(sender, manifestID, payment.GetExpectedPrice(), streamed.tokensUsed, cap, modelID)
+		return
+	}
 
-	// At the moment, outPixels is expected to just be height * width * frames
-	// If the # of inference/denoising steps becomes configurable, a possible updated formula could be height * width * frames * steps
-	// If additional parameters that influence compute cost become configurable, then the formula should be reconsidered
-	orch.DebitFees(sender, manifestID, payment.GetExpectedPrice(), outPixels)
+	units, _, err := estimator.EstimateUnits(ctx)
+	if err != nil {
+		respondWithError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// The LLM capability does not know its token count until the worker's response comes back, so
+	// its estimator's placeholder value is overridden here.
+	if llmResp, ok := resp.(*worker.LLMResponse); ok {
+		units = int64(llmResp.TokensUsed)
+	}
+	orch.DebitFees(sender, manifestID, payment.GetExpectedPrice(), units, cap, modelID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+
+	if imgResp, ok := resp.(*worker.ImageResponse); ok {
+		seeds := make([]uint64, len(imgResp.Images))
+		for i, img := range imgResp.Images {
+			seeds[i] = img.Seed
+		}
+
+		_ = json.NewEncoder(w).Encode(imageResponseEnvelope{
+			Images: imgResp.Images,
+			RequestMetadata: RequestMetadata{
+				RequestID:   requestID,
+				ModelID:     modelID,
+				Capability:  cap.String(),
+				TookMs:      took.Milliseconds(),
+				OutputUnits: units,
+				Seeds:       seeds,
+			},
+		})
+		return
+	}
+
 	_ = json.NewEncoder(w).Encode(resp)
-}
\ No newline at end of file
+}
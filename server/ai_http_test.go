@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/livepeer/go-livepeer/core"
+	"github.com/livepeer/go-livepeer/net"
+)
+
+func TestSufficientPrice(t *testing.T) {
+	tests := []struct {
+		name       string
+		expected   *net.PriceInfo
+		configured *net.PriceInfo
+		want       bool
+	}{
+		{
+			name:       "no payment accepted when orchestrator has no configured price",
+			expected:   nil,
+			configured: &net.PriceInfo{PricePerUnit: 0, PixelsPerUnit: 1},
+			want:       true,
+		},
+		{
+			name:       "no payment offered against a configured price",
+			expected:   nil,
+			configured: &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+			want:       false,
+		},
+		{
+			name:       "expected price below configured price",
+			expected:   &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 2},
+			configured: &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+			want:       false,
+		},
+		{
+			name:       "expected price meets configured price",
+			expected:   &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+			configured: &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+			want:       true,
+		},
+		{
+			name:       "expected price exceeds configured price",
+			expected:   &net.PriceInfo{PricePerUnit: 2, PixelsPerUnit: 1},
+			configured: &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+			want:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sufficientPrice(tt.expected, tt.configured); got != tt.want {
+				t.Errorf("sufficientPrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// stubOrchestrator implements Orchestrator with fields to control PriceInfo/ProcessPayment's
+// return values; every other method panics if called since the pricing tests never reach them.
+type stubOrchestrator struct {
+	Orchestrator
+
+	price    *net.PriceInfo
+	priceErr error
+
+	processPaymentErr error
+
+	sufficientBalance bool
+}
+
+func (o *stubOrchestrator) PriceInfo(sender ethcommon.Address, manifestID core.ManifestID, cap core.Capability, modelID string) (*net.PriceInfo, error) {
+	return o.price, o.priceErr
+}
+
+func (o *stubOrchestrator) ProcessPayment(ctx context.Context, payment *net.Payment, manifestID core.ManifestID, cap core.Capability, modelID string) error {
+	return o.processPaymentErr
+}
+
+func (o *stubOrchestrator) SufficientBalance(sender ethcommon.Address, manifestID core.ManifestID) bool {
+	return o.sufficientBalance
+}
+
+func TestCheckAndProcessPayment_UnknownModel(t *testing.T) {
+	orch := &stubOrchestrator{priceErr: errors.New("no price set for capability + modelID")}
+	w := httptest.NewRecorder()
+
+	ok := checkAndProcessPayment(context.Background(), w, orch, ethcommon.Address{}, &net.Payment{}, core.ManifestID("0_unknown-model"), core.Capability_TextToImage, "unknown-model")
+
+	if ok {
+		t.Fatal("expected checkAndProcessPayment to fail for an unpriced model")
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestCheckAndProcessPayment_PriceMismatch(t *testing.T) {
+	orch := &stubOrchestrator{price: &net.PriceInfo{PricePerUnit: 10, PixelsPerUnit: 1}}
+	payment := &net.Payment{ExpectedPrice: &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1}}
+	w := httptest.NewRecorder()
+
+	ok := checkAndProcessPayment(context.Background(), w, orch, ethcommon.Address{}, payment, core.ManifestID("0_model"), core.Capability_TextToImage, "model")
+
+	if ok {
+		t.Fatal("expected checkAndProcessPayment to fail when payment is below the configured price")
+	}
+	if w.Code != http.StatusPaymentRequired {
+		t.Errorf("status = %v, want %v", w.Code, http.StatusPaymentRequired)
+	}
+}
+
+func TestCheckAndProcessPayment_NoConfiguredPrice(t *testing.T) {
+	orch := &stubOrchestrator{price: &net.PriceInfo{PricePerUnit: 0, PixelsPerUnit: 1}, sufficientBalance: true}
+	w := httptest.NewRecorder()
+
+	ok := checkAndProcessPayment(context.Background(), w, orch, ethcommon.Address{}, &net.Payment{}, core.ManifestID("0_model"), core.Capability_TextToImage, "model")
+
+	if !ok {
+		t.Fatalf("expected checkAndProcessPayment to succeed with no configured price, got status %v", w.Code)
+	}
+}
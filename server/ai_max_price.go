@@ -0,0 +1,91 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/livepeer/go-livepeer/core"
+	"github.com/livepeer/go-livepeer/net"
+)
+
+// aiMaxPriceDefaultModelID is the ModelID an AIMaxPriceTable entry is stored/looked up under when
+// it applies to every modelID for a capability rather than one specifically.
+const aiMaxPriceDefaultModelID = "default"
+
+// aiMaxPriceKey identifies one AIMaxPriceTable entry: a capability, and either a specific modelID
+// or aiMaxPriceDefaultModelID for that capability's fallback.
+type aiMaxPriceKey struct {
+	Capability core.Capability
+	ModelID    string
+}
+
+// AIMaxPriceTable is the gateway-side counterpart to Orchestrator.PriceInfo: the maximum price per
+// unit a gateway is willing to pay for a given (capability, modelID), so it can reject an
+// orchestrator's quoted price before paying for a job rather than only bounding what the
+// orchestrator itself will accept. A lookup for (cap, modelID) falls back to that capability's
+// "default" entry when no entry exists for modelID specifically.
+//
+// This tree has no gateway/broadcaster package of its own (see cmd/livepeer's price-selection
+// logic in the full repo) for this table to plug into; it is added here, alongside the
+// orchestrator-side pricing it complements, for a gateway-side caller to use once one exists.
+type AIMaxPriceTable struct {
+	mu     sync.RWMutex
+	prices map[aiMaxPriceKey]*net.PriceInfo
+}
+
+// NewAIMaxPriceTable creates an empty AIMaxPriceTable.
+func NewAIMaxPriceTable() *AIMaxPriceTable {
+	return &AIMaxPriceTable{prices: make(map[aiMaxPriceKey]*net.PriceInfo)}
+}
+
+// SetMaxPrice sets the max price for cap+modelID.
+func (t *AIMaxPriceTable) SetMaxPrice(cap core.Capability, modelID string, price *net.PriceInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prices[aiMaxPriceKey{Capability: cap, ModelID: modelID}] = price
+}
+
+// SetDefaultMaxPrice sets the max price used for cap when no modelID-specific entry applies.
+func (t *AIMaxPriceTable) SetDefaultMaxPrice(cap core.Capability, price *net.PriceInfo) {
+	t.SetMaxPrice(cap, aiMaxPriceDefaultModelID, price)
+}
+
+// MaxPrice returns the configured max price for cap+modelID, falling back to cap's default entry
+// if modelID has none of its own, and ok=false if neither is configured.
+func (t *AIMaxPriceTable) MaxPrice(cap core.Capability, modelID string) (price *net.PriceInfo, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if price, ok := t.prices[aiMaxPriceKey{Capability: cap, ModelID: modelID}]; ok {
+		return price, true
+	}
+
+	price, ok = t.prices[aiMaxPriceKey{Capability: cap, ModelID: aiMaxPriceDefaultModelID}]
+	return price, ok
+}
+
+// Acceptable reports whether price is within the configured max price for cap+modelID. A
+// capability+modelID with no configured max price accepts any price, mirroring
+// sufficientPrice's treatment of an orchestrator with no configured price.
+func (t *AIMaxPriceTable) Acceptable(cap core.Capability, modelID string, price *net.PriceInfo) bool {
+	max, ok := t.MaxPrice(cap, modelID)
+	if !ok {
+		return true
+	}
+
+	return priceWithinMax(price, max)
+}
+
+// priceWithinMax reports whether price does not exceed max. Per-unit prices are fractions
+// (price/pixels), so they are compared by cross-multiplication to avoid division, the same way
+// sufficientPrice compares a gateway's offered price against an orchestrator's configured one.
+func priceWithinMax(price, max *net.PriceInfo) bool {
+	if max.GetPricePerUnit() == 0 {
+		return true
+	}
+	if price == nil {
+		return false
+	}
+
+	return price.GetPricePerUnit()*max.GetPixelsPerUnit() <= max.GetPricePerUnit()*price.GetPixelsPerUnit()
+}
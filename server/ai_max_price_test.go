@@ -0,0 +1,102 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/livepeer/go-livepeer/core"
+	"github.com/livepeer/go-livepeer/net"
+)
+
+func TestAIMaxPriceTable_MaxPrice(t *testing.T) {
+	table := NewAIMaxPriceTable()
+	table.SetMaxPrice(core.Capability_TextToImage, "specific-model", &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1})
+	table.SetDefaultMaxPrice(core.Capability_TextToImage, &net.PriceInfo{PricePerUnit: 2, PixelsPerUnit: 1})
+
+	tests := []struct {
+		name      string
+		cap       core.Capability
+		modelID   string
+		wantPrice *net.PriceInfo
+		wantOk    bool
+	}{
+		{
+			name:      "modelID-specific entry",
+			cap:       core.Capability_TextToImage,
+			modelID:   "specific-model",
+			wantPrice: &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+			wantOk:    true,
+		},
+		{
+			name:      "falls back to default entry for an unconfigured modelID",
+			cap:       core.Capability_TextToImage,
+			modelID:   "unconfigured-model",
+			wantPrice: &net.PriceInfo{PricePerUnit: 2, PixelsPerUnit: 1},
+			wantOk:    true,
+		},
+		{
+			name:    "no entry and no default for a different capability",
+			cap:     core.Capability_Upscale,
+			modelID: "unconfigured-model",
+			wantOk:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, ok := table.MaxPrice(tt.cap, tt.modelID)
+			if ok != tt.wantOk {
+				t.Fatalf("MaxPrice() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && (price.GetPricePerUnit() != tt.wantPrice.GetPricePerUnit() || price.GetPixelsPerUnit() != tt.wantPrice.GetPixelsPerUnit()) {
+				t.Errorf("MaxPrice() = %v, want %v", price, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestAIMaxPriceTable_Acceptable(t *testing.T) {
+	tests := []struct {
+		name  string
+		max   *net.PriceInfo
+		price *net.PriceInfo
+		want  bool
+	}{
+		{
+			name:  "no max price configured accepts any price",
+			max:   nil,
+			price: &net.PriceInfo{PricePerUnit: 1000, PixelsPerUnit: 1},
+			want:  true,
+		},
+		{
+			name:  "price within max",
+			max:   &net.PriceInfo{PricePerUnit: 2, PixelsPerUnit: 1},
+			price: &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+			want:  true,
+		},
+		{
+			name:  "price exceeds max",
+			max:   &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+			price: &net.PriceInfo{PricePerUnit: 2, PixelsPerUnit: 1},
+			want:  false,
+		},
+		{
+			name:  "nil price against a configured max",
+			max:   &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+			price: nil,
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := NewAIMaxPriceTable()
+			if tt.max != nil {
+				table.SetDefaultMaxPrice(core.Capability_TextToImage, tt.max)
+			}
+
+			if got := table.Acceptable(core.Capability_TextToImage, "some-model", tt.price); got != tt.want {
+				t.Errorf("Acceptable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
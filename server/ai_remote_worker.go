@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/livepeer/ai-worker/worker"
+	"github.com/livepeer/go-livepeer/core"
+)
+
+// remoteAIWorkers tracks external GPU worker processes that register with this orchestrator over
+// the /ai/remote-worker HTTP endpoints, so that handleAIRequest can dispatch a job to one of them
+// instead of running it against the in-process worker.
+//
+// Known gap: AudioToText - the capability whose multipart uploads (audio) are actually large
+// enough to need remote offload - can never reach a remote worker through this manager. Its
+// handler streams straight off the connection into a bounded temp file instead of buffering a
+// rawBody to forward (see probeAudioDurationSecs and the AudioToText handler in ai_http.go), so
+// handleAIRequest's remote-dispatch check (rawBody != nil) always skips it. Supporting it would
+// need dispatchToRemoteAIWorker to forward from that temp file rather than a []byte.
+var remoteAIWorkers = core.NewRemoteAIWorkerManager(context.Background())
+
+// remoteAIWorkerJobTimeout bounds how long handleAIRequest waits for a dispatched remote job to
+// be picked up and completed before falling back to ErrRemoteAIWorkerTimeout.
+const remoteAIWorkerJobTimeout = 60 * time.Second
+
+// remoteAIWorkerPollTimeout bounds a single /ai/remote-worker/jobs long-poll, so idle connections
+// don't hold a goroutine open indefinitely and the worker can periodically re-check its context.
+const remoteAIWorkerPollTimeout = 25 * time.Second
+
+// registerAIRemoteWorkerRoutes wires the HTTP endpoints a remote AI worker process uses to
+// register, advertise warm models, pull queued jobs, and post results back, mirroring (over
+// HTTP/2 instead of gRPC, since this tree has no protoc-generated stubs to build on) the
+// register/poll/report lifecycle of the existing remote transcoder protocol.
+func registerAIRemoteWorkerRoutes(lp lphttp) {
+	lp.transRPC.Handle("/ai/remote-worker/register", lp.RemoteAIWorkerRegister())
+	lp.transRPC.Handle("/ai/remote-worker/heartbeat", lp.RemoteAIWorkerHeartbeat())
+	lp.transRPC.Handle("/ai/remote-worker/jobs", lp.RemoteAIWorkerJobs())
+	lp.transRPC.Handle("/ai/remote-worker/result", lp.RemoteAIWorkerResult())
+}
+
+// requireMTLSClientCert reports whether r arrived over a connection that presented a client
+// certificate. The listener terminating TLS is expected to be configured to request (and this
+// handler to enforce) a client cert for these endpoints, since a remote worker is trusted with
+// inference jobs and should be authenticated the same way an RPC-connected transcoder is.
+func requireMTLSClientCert(w http.ResponseWriter, r *http.Request) bool {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		respondWithError(w, "remote AI worker endpoints require an mTLS client certificate", http.StatusUnauthorized)
+		return false
+	}
+
+	return true
+}
+
+// remoteAIWorkerCapabilities parses the (capability, modelID, warm) tuples a remote worker
+// advertises in its register/heartbeat request body.
+func remoteAIWorkerCapabilities(r *http.Request) ([]core.AIWorkerCapability, error) {
+	var body struct {
+		Capabilities []struct {
+			Capability core.Capability `json:"capability"`
+			ModelID    string          `json:"model_id"`
+			Warm       bool            `json:"warm"`
+		} `json:"capabilities"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	caps := make([]core.AIWorkerCapability, len(body.Capabilities))
+	for i, c := range body.Capabilities {
+		caps[i] = core.AIWorkerCapability{
+			Key:  core.AIJobKey{Capability: c.Capability, ModelID: c.ModelID},
+			Warm: c.Warm,
+		}
+	}
+
+	return caps, nil
+}
+
+// RemoteAIWorkerRegister handles a remote worker's initial registration, in which it advertises
+// the (capability, modelID) pairs it can serve and which of them are already warm, and returns
+// the worker ID it must use on subsequent heartbeat/poll/result calls.
+func (h *lphttp) RemoteAIWorkerRegister() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !requireMTLSClientCert(w, r) {
+			return
+		}
+
+		warm, err := remoteAIWorkerCapabilities(r)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		worker := core.NewRemoteAIWorker(r.TLS.PeerCertificates[0].Subject.CommonName+"-"+string(core.RandomManifestID()), warm)
+		remoteAIWorkers.Register(worker)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			WorkerID string `json:"worker_id"`
+		}{WorkerID: worker.ID})
+	})
+}
+
+// RemoteAIWorkerHeartbeat refreshes a registered worker's last-seen time and its advertised
+// (capability, modelID) pairs and warm set.
+func (h *lphttp) RemoteAIWorkerHeartbeat() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !requireMTLSClientCert(w, r) {
+			return
+		}
+
+		worker := remoteAIWorkers.Worker(r.URL.Query().Get("worker_id"))
+		if worker == nil {
+			respondWithError(w, "unknown worker", http.StatusNotFound)
+			return
+		}
+
+		warm, err := remoteAIWorkerCapabilities(r)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		worker.Heartbeat(warm)
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// RemoteAIWorkerJobs is long-polled by a registered worker to receive its next dispatched job.
+// It returns 204 with no body if nothing was queued before remoteAIWorkerPollTimeout elapses, so
+// the worker can simply re-issue the request in a loop.
+func (h *lphttp) RemoteAIWorkerJobs() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !requireMTLSClientCert(w, r) {
+			return
+		}
+
+		worker := remoteAIWorkers.Worker(r.URL.Query().Get("worker_id"))
+		if worker == nil {
+			respondWithError(w, "unknown worker", http.StatusNotFound)
+			return
+		}
+
+		job, err := worker.NextJob(r.Context(), remoteAIWorkerPollTimeout)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusRequestTimeout)
+			return
+		}
+		if job == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Content-Type", job.ContentType)
+		w.Header().Set("X-Livepeer-Job-Id", job.ID)
+		w.Header().Set("X-Livepeer-Capability", strconv.Itoa(int(job.Key.Capability)))
+		w.Header().Set("X-Livepeer-Model-Id", job.Key.ModelID)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(job.Body)
+	})
+}
+
+// RemoteAIWorkerResult accepts a worker's response for a job it previously long-polled for
+// /ai/remote-worker/jobs, identified by the X-Livepeer-Job-Id the worker was given, and delivers
+// it to the handleAIRequest call blocked in Dispatch.
+func (h *lphttp) RemoteAIWorkerResult() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !requireMTLSClientCert(w, r) {
+			return
+		}
+
+		jobID := r.URL.Query().Get("job_id")
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			respondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		res := core.RemoteAIWorkerResult{ContentType: r.Header.Get("Content-Type"), Body: body}
+		if errMsg := r.Header.Get("X-Livepeer-Error"); errMsg != "" {
+			res.Err = errors.New(errMsg)
+		}
+
+		if !remoteAIWorkers.CompleteJob(jobID, res) {
+			respondWithError(w, "unknown or expired job", http.StatusGone)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// pickRemoteAIWorker looks up a connected, capable remote worker for key, returning nil if none
+// is registered, so the caller can decide up front whether a job will run remotely (and skip the
+// in-process EnsureWarm accordingly) without yet paying for the round trip itself.
+func pickRemoteAIWorker(key core.AIJobKey) *core.RemoteAIWorker {
+	return remoteAIWorkers.Pick(key)
+}
+
+// dispatchToRemoteAIWorker ships the original request body (contentType/body, exactly as received
+// from the gateway, multipart boundary and all) to w over the register/poll/result protocol and
+// blocks until it responds. Callers should run this inside the function passed to
+// aiJobPool.Submit, so the round trip counts against that (capability, modelID)'s concurrency and
+// timing the same as an in-process job would.
+func dispatchToRemoteAIWorker(ctx context.Context, w *core.RemoteAIWorker, key core.AIJobKey, contentType string, body []byte) (*core.RemoteAIWorkerResult, error) {
+	return remoteAIWorkers.Dispatch(ctx, key, remoteAIWorkerJobTimeout, w, contentType, body)
+}
+
+// parseRemoteAIWorkerResponse decodes a remote worker's JSON response body into the same type
+// handleAIRequest's in-process submitFn would have returned for cap, so the two paths can share
+// the rest of handleAIRequest's billing/response-writing logic.
+func parseRemoteAIWorkerResponse(cap core.Capability, body []byte) (interface{}, error) {
+	switch cap {
+	case core.Capability_AudioToText:
+		var res worker.TextResponse
+		if err := json.Unmarshal(body, &res); err != nil {
+			return nil, err
+		}
+		return &res, nil
+	case core.Capability_LLM:
+		var res worker.LLMResponse
+		if err := json.Unmarshal(body, &res); err != nil {
+			return nil, err
+		}
+		return &res, nil
+	default:
+		var res worker.ImageResponse
+		if err := json.Unmarshal(body, &res); err != nil {
+			return nil, err
+		}
+		return &res, nil
+	}
+}
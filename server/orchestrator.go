@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/livepeer/ai-worker/worker"
+	"github.com/livepeer/go-livepeer/core"
+	"github.com/livepeer/go-livepeer/net"
+)
+
+// Orchestrator is the set of orchestrator operations the AI HTTP handlers need: running an AI
+// inference job for each capability, and pricing/authorizing/billing it per (capability,
+// modelID), the same way transcoding work is priced/authorized/billed per manifestID.
+type Orchestrator interface {
+	TextToImage(ctx context.Context, req worker.TextToImageJSONRequestBody) (*worker.ImageResponse, error)
+	ImageToImage(ctx context.Context, req worker.ImageToImageMultipartRequestBody) (*worker.ImageResponse, error)
+	ImageToVideo(ctx context.Context, req worker.ImageToVideoMultipartRequestBody) (*worker.ImageResponse, error)
+	TextToVideo(ctx context.Context, req worker.TextToVideoJSONRequestBody) (*worker.ImageResponse, error)
+	LLM(ctx context.Context, req worker.LLMFormdataRequestBody) (interface{}, error)
+	AudioToText(ctx context.Context, req worker.AudioToTextMultipartRequestBody) (*worker.TextResponse, error)
+	Upscale(ctx context.Context, req worker.UpscaleMultipartRequestBody) (*worker.ImageResponse, error)
+
+	// Warm loads the model for cap+modelID so the next TextToImage/.../Upscale call for it
+	// doesn't pay the cold-start cost. It is called the first time a local request for it
+	// arrives, and again after a failed call or once the job pool has evicted that pair's idle
+	// queue.
+	Warm(ctx context.Context, cap core.Capability, modelID string) error
+
+	// PriceInfo returns the orchestrator's configured price for cap+modelID, independently of any
+	// other capability+modelID, so a gateway's payment can be checked against the right price
+	// before the job runs.
+	PriceInfo(sender ethcommon.Address, manifestID core.ManifestID, cap core.Capability, modelID string) (*net.PriceInfo, error)
+	// ProcessPayment records payment for a (capability, modelID) session identified by
+	// manifestID, mirroring the existing per-manifestID transcoding payment flow.
+	ProcessPayment(ctx context.Context, payment *net.Payment, manifestID core.ManifestID, cap core.Capability, modelID string) error
+	SufficientBalance(sender ethcommon.Address, manifestID core.ManifestID) bool
+	// DebitFees debits units worth of price from the sender's balance for manifestID, scoped to
+	// cap+modelID so usage is tracked independently per AI capability and model.
+	DebitFees(sender ethcommon.Address, manifestID core.ManifestID, price *net.PriceInfo, units int64, cap core.Capability, modelID string)
+}